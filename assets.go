@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// postSource locates a post's markdown file and, for page bundles, the
+// directory holding its sibling assets.
+type postSource struct {
+	slug      string
+	mdPath    string
+	bundleDir string // empty for flat posts/<slug>.md posts
+}
+
+// discoverPostSources finds both flat posts (posts/<slug>.md) and page
+// bundles (posts/<slug>/index.md with co-located assets).
+func discoverPostSources() ([]postSource, error) {
+	var sources []postSource
+
+	flatFiles, err := filepath.Glob("posts/*.md")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range flatFiles {
+		slug := strings.TrimSuffix(strings.TrimPrefix(f, "posts/"), ".md")
+		sources = append(sources, postSource{slug: slug, mdPath: f})
+	}
+
+	bundleFiles, err := filepath.Glob("posts/*/index.md")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range bundleFiles {
+		dir := filepath.Dir(f)
+		sources = append(sources, postSource{slug: filepath.Base(dir), mdPath: f, bundleDir: dir})
+	}
+
+	return sources, nil
+}
+
+// copyPostAssets copies every non-markdown sibling file in a post bundle's
+// directory into public/posts/<slug>/.
+func copyPostAssets(src postSource) error {
+	if src.bundleDir == "" {
+		return nil
+	}
+
+	outDir := filepath.Join("public/posts", src.slug)
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("post asset dir error: %v", err)
+	}
+
+	return filepath.WalkDir(src.bundleDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(p) == ".md" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src.bundleDir, p)
+		if err != nil {
+			return err
+		}
+
+		return copyFile(p, filepath.Join(outDir, rel))
+	})
+}
+
+// pruneStaleBundleAssets removes public/posts/<slug> directories left
+// behind by a bundle post that's since been renamed or deleted, since
+// public/ is never wiped wholesale between builds.
+func pruneStaleBundleAssets(postsData []PostData) error {
+	live := make(map[string]bool)
+	for _, post := range postsData {
+		if post.BundleDir != "" {
+			live[post.Metadata.Slug] = true
+		}
+	}
+
+	entries, err := os.ReadDir("public/posts")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("stale bundle asset scan error: %v", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || live[entry.Name()] {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join("public/posts", entry.Name())); err != nil {
+			return fmt.Errorf("stale bundle asset removal error: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func isRelativeAssetURL(url string) bool {
+	return url != "" && !strings.HasPrefix(url, "/") && !strings.Contains(url, "://")
+}
+
+// rewritePostAssetURLs resolves relative image and link URLs in a bundle
+// post's rendered HTML against its public output directory, and replaces
+// images with a responsive <picture> built from resizeImageVariants.
+func rewritePostAssetURLs(htmlStr string, src postSource, cache imageCache) (string, error) {
+	if src.bundleDir == "" {
+		return htmlStr, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlStr))
+	if err != nil {
+		return "", fmt.Errorf("asset URL rewrite parse error: %v", err)
+	}
+
+	var rewriteErr error
+	doc.Find("img").EachWithBreak(func(_ int, img *goquery.Selection) bool {
+		if err := rewriteImage(img, src, cache); err != nil {
+			rewriteErr = err
+			return false
+		}
+		return true
+	})
+	if rewriteErr != nil {
+		return "", rewriteErr
+	}
+
+	doc.Find("a").Each(func(_ int, a *goquery.Selection) {
+		href, ok := a.Attr("href")
+		if !ok || !isRelativeAssetURL(href) {
+			return
+		}
+		a.SetAttr("href", "/posts/"+src.slug+"/"+href)
+	})
+
+	body, err := doc.Find("body").Html()
+	if err != nil {
+		return "", fmt.Errorf("asset URL rewrite render error: %v", err)
+	}
+
+	return body, nil
+}
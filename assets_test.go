@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestIsRelativeAssetURL(t *testing.T) {
+	cases := map[string]bool{
+		"foo.png":             true,
+		"./foo.png":           true,
+		"/foo.png":            false,
+		"https://x.com/a.png": false,
+	}
+
+	for in, want := range cases {
+		if got := isRelativeAssetURL(in); got != want {
+			t.Errorf("isRelativeAssetURL(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestRewritePostAssetURLsFlatPostIsUnchanged(t *testing.T) {
+	html := `<p><a href="foo.html">foo</a></p>`
+	src := postSource{slug: "hello"}
+
+	out, err := rewritePostAssetURLs(html, src, loadImageCache(t.TempDir()+"/missing.json"))
+	if err != nil {
+		t.Fatalf("rewritePostAssetURLs returned error: %v", err)
+	}
+
+	if out != html {
+		t.Errorf("expected flat post HTML unchanged, got %q", out)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aroki1/blog-source/internal/builder"
+)
+
+const buildCachePath = ".build-cache.json"
+
+// siteCacheKey is the reserved build cache entry for config.toml and the
+// non-post templates (index/tags/tag/about), which affect every generated
+// page rather than a single post. Its TemplateHash lets a build tell
+// whether any of them changed since the last run.
+const siteCacheKey = "__site__"
+
+// cacheEntry records what a post's rendered output was derived from, so a
+// later build can tell whether it needs to re-render.
+type cacheEntry struct {
+	SourceHash   string `json:"sourceHash"`
+	TemplateHash string `json:"templateHash"`
+	OutputPath   string `json:"outputPath"`
+}
+
+type buildCache map[string]cacheEntry
+
+func loadBuildCache(path string) buildCache {
+	cache := make(buildCache)
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return make(buildCache)
+	}
+
+	return cache
+}
+
+func saveBuildCache(path string, cache buildCache) error {
+	b, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("build cache encode error: %v", err)
+	}
+
+	return os.WriteFile(path, b, 0644)
+}
+
+// hashTemplates returns a single hash representing the combined content of
+// every template file passed in, so a change to any of them invalidates
+// the cache entries that depend on them.
+func hashTemplates(paths ...string) (string, error) {
+	var hasher builder.Hasher
+
+	combined := ""
+	for _, path := range paths {
+		h, err := hasher.Hash(path)
+		if err != nil {
+			return "", err
+		}
+		combined += h
+	}
+
+	return combined, nil
+}
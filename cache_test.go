@@ -0,0 +1,30 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".build-cache.json")
+
+	cache := loadBuildCache(path)
+	if len(cache) != 0 {
+		t.Fatalf("expected empty cache for missing file, got %v", cache)
+	}
+
+	cache["hello-world"] = cacheEntry{
+		SourceHash:   "abc123",
+		TemplateHash: "def456",
+		OutputPath:   "public/posts/hello-world.html",
+	}
+
+	if err := saveBuildCache(path, cache); err != nil {
+		t.Fatalf("saveBuildCache returned error: %v", err)
+	}
+
+	reloaded := loadBuildCache(path)
+	if reloaded["hello-world"] != cache["hello-world"] {
+		t.Errorf("reloaded cache entry = %+v, want %+v", reloaded["hello-world"], cache["hello-world"])
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SiteConfig holds site-wide settings loaded from config.toml.
+type SiteConfig struct {
+	Title    string `toml:"title"`
+	BaseURL  string `toml:"base_url"`
+	Author   string `toml:"author"`
+	Language string `toml:"language"`
+}
+
+func loadSiteConfig(path string) (SiteConfig, error) {
+	var cfg SiteConfig
+
+	_, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		return SiteConfig{}, fmt.Errorf("config load error: %v", err)
+	}
+
+	return cfg, nil
+}
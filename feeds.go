@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aroki1/blog-source/internal/feed"
+)
+
+// GenerateFeeds writes the site-wide Atom feed plus one per-tag Atom feed
+// into public/, derived from postsData.
+func GenerateFeeds(cfg SiteConfig, postsData []PostData) error {
+	if err := writeFeed(cfg, "public/feed.xml", cfg.BaseURL+"/feed.xml", postsData); err != nil {
+		return err
+	}
+
+	// Grouped the same way as the tag HTML pages (buildTagIndex), so tags
+	// that collide after slugifying (e.g. "Go" and "GO") share one feed
+	// instead of nondeterministically clobbering each other's.
+	byTag := buildTagIndex(postsData)
+
+	for tag, posts := range byTag {
+		slug := SlugifyTag(tag)
+		dir := filepath.Join("public/tags", slug)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("tag feed dir error: %v", err)
+		}
+
+		feedURL := fmt.Sprintf("%s/tags/%s/feed.xml", cfg.BaseURL, slug)
+		if err := writeFeed(cfg, filepath.Join(dir, "feed.xml"), feedURL, posts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GenerateLanguageFeeds writes public/<lang>/feed.xml for every language
+// partition, so each language has its own Atom feed.
+func GenerateLanguageFeeds(cfg SiteConfig, byLang map[string][]PostData) error {
+	for lang, posts := range byLang {
+		outPath := filepath.Join("public", lang, "feed.xml")
+		feedURL := fmt.Sprintf("%s/%s/feed.xml", cfg.BaseURL, lang)
+
+		if err := writeFeed(cfg, outPath, feedURL, posts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// postURL returns a post's canonical, language-scoped absolute URL.
+func postURL(cfg SiteConfig, post PostData) string {
+	return cfg.BaseURL + "/" + post.Metadata.Language + "/posts/" + post.Metadata.Slug + ".html"
+}
+
+// writeFeed renders postsData as an Atom feed at outPath, linking each
+// entry to its post's canonical language-scoped URL.
+func writeFeed(cfg SiteConfig, outPath, feedURL string, postsData []PostData) error {
+	f := feed.Feed{
+		Title:   cfg.Title,
+		Author:  cfg.Author,
+		FeedURL: feedURL,
+		SiteURL: cfg.BaseURL,
+	}
+
+	for _, post := range postsData {
+		if post.Metadata.Date.After(f.Updated) {
+			f.Updated = post.Metadata.Date
+		}
+
+		f.Entries = append(f.Entries, feed.Entry{
+			Title:       post.Metadata.Title,
+			Description: post.Metadata.Description,
+			URL:         postURL(cfg, post),
+			Updated:     post.Metadata.Date,
+			Content:     string(post.Content),
+		})
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return fmt.Errorf("feed dir creation error: %v", err)
+	}
+
+	file, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("feed file creation error: %v", err)
+	}
+	defer file.Close()
+
+	return feed.WriteAtom(file, f)
+}
+
+// GenerateSitemap writes public/sitemap.xml listing every page produced by
+// the build.
+func GenerateSitemap(cfg SiteConfig, postsData []PostData) error {
+	var sb strings.Builder
+
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	sb.WriteString(sitemapURL(cfg.BaseURL + "/"))
+
+	for _, post := range postsData {
+		sb.WriteString(sitemapURL(postURL(cfg, post)))
+	}
+
+	sb.WriteString(`</urlset>` + "\n")
+
+	return os.WriteFile("public/sitemap.xml", []byte(sb.String()), 0644)
+}
+
+func sitemapURL(loc string) string {
+	return fmt.Sprintf("  <url><loc>%s</loc></url>\n", loc)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// partitionByLanguage groups posts by PostMetadata.Language, falling back
+// to defaultLang for posts that don't set one.
+func partitionByLanguage(postsData []PostData, defaultLang string) map[string][]PostData {
+	byLang := make(map[string][]PostData)
+
+	for _, post := range postsData {
+		lang := post.Metadata.Language
+		if lang == "" {
+			lang = defaultLang
+		}
+		byLang[lang] = append(byLang[lang], post)
+	}
+
+	return byLang
+}
+
+// populateTranslations sets PostData.Translations, mapping language to
+// absolute URL, on every post that shares a Metadata.TranslationKey with
+// at least one other post.
+func populateTranslations(postsData []PostData, cfg SiteConfig) {
+	byKey := make(map[string][]int)
+	for i, post := range postsData {
+		if post.Metadata.TranslationKey == "" {
+			continue
+		}
+		byKey[post.Metadata.TranslationKey] = append(byKey[post.Metadata.TranslationKey], i)
+	}
+
+	for _, indices := range byKey {
+		if len(indices) < 2 {
+			continue
+		}
+
+		urls := make(map[string]string, len(indices))
+		for _, i := range indices {
+			lang := postsData[i].Metadata.Language
+			if lang == "" {
+				lang = cfg.Language
+			}
+			urls[lang] = fmt.Sprintf("%s/%s/posts/%s.html", cfg.BaseURL, lang, postsData[i].Metadata.Slug)
+		}
+
+		for _, i := range indices {
+			postsData[i].Translations = urls
+		}
+	}
+}
+
+// ParseRootRedirectPage writes public/index.html as a meta-refresh
+// redirect to the default language's home page, with hreflang alternates
+// for every language the build produced.
+func ParseRootRedirectPage(cfg SiteConfig, langs []string) error {
+	alternates := ""
+	for _, lang := range langs {
+		alternates += fmt.Sprintf(`  <link rel="alternate" hreflang="%s" href="%s/%s/">`+"\n", lang, cfg.BaseURL, lang)
+	}
+
+	html := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="%s">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="0; url=/%s/">
+<link rel="canonical" href="%s/%s/">
+%s</head>
+<body>
+<p>Redirecting to <a href="/%s/">%s</a>.</p>
+</body>
+</html>
+`, cfg.Language, cfg.Language, cfg.BaseURL, cfg.Language, alternates, cfg.Language, cfg.Title)
+
+	return os.WriteFile("public/index.html", []byte(html), 0644)
+}
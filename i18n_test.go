@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestPartitionByLanguageFallsBackToDefault(t *testing.T) {
+	posts := []PostData{
+		{Metadata: PostMetadata{Slug: "a", Language: "fr"}},
+		{Metadata: PostMetadata{Slug: "b"}},
+	}
+
+	byLang := partitionByLanguage(posts, "en")
+
+	if len(byLang["fr"]) != 1 || byLang["fr"][0].Metadata.Slug != "a" {
+		t.Errorf("expected post a under fr, got %v", byLang["fr"])
+	}
+	if len(byLang["en"]) != 1 || byLang["en"][0].Metadata.Slug != "b" {
+		t.Errorf("expected post b under default en, got %v", byLang["en"])
+	}
+}
+
+func TestPopulateTranslationsLinksSharedKey(t *testing.T) {
+	posts := []PostData{
+		{Metadata: PostMetadata{Slug: "hello", Language: "en", TranslationKey: "hello"}},
+		{Metadata: PostMetadata{Slug: "bonjour", Language: "fr", TranslationKey: "hello"}},
+		{Metadata: PostMetadata{Slug: "solo", Language: "en"}},
+	}
+	cfg := SiteConfig{BaseURL: "https://example.com", Language: "en"}
+
+	populateTranslations(posts, cfg)
+
+	if posts[0].Translations["fr"] != "https://example.com/fr/posts/bonjour.html" {
+		t.Errorf("expected hello to link to the fr translation, got %v", posts[0].Translations)
+	}
+	if posts[1].Translations["en"] != "https://example.com/en/posts/hello.html" {
+		t.Errorf("expected bonjour to link to the en translation, got %v", posts[1].Translations)
+	}
+	if posts[2].Translations != nil {
+		t.Errorf("expected untranslated post to have no Translations, got %v", posts[2].Translations)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/disintegration/imaging"
+
+	"github.com/aroki1/blog-source/internal/builder"
+)
+
+// responsiveWidths are the srcset breakpoints generated for every
+// bundle-post image, skipping any width not smaller than the source.
+var responsiveWidths = []int{480, 960, 1920}
+
+const imageCachePath = ".image-cache.json"
+
+type imageCacheEntry struct {
+	SourceHash string   `json:"sourceHash"`
+	Variants   []string `json:"variants"`
+}
+
+type imageCache map[string]imageCacheEntry
+
+func loadImageCache(path string) imageCache {
+	cache := make(imageCache)
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return make(imageCache)
+	}
+
+	return cache
+}
+
+func saveImageCache(path string, cache imageCache) error {
+	b, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("image cache encode error: %v", err)
+	}
+
+	return os.WriteFile(path, b, 0644)
+}
+
+type imageVariant struct {
+	width int
+	name  string
+}
+
+// resizeImageVariants generates 480/960/1920-wide derivatives of srcPath
+// into outDir, reusing cached derivatives when srcPath's content hash is
+// unchanged since the last build.
+func resizeImageVariants(srcPath, outDir, srcName string, cache imageCache) ([]imageVariant, error) {
+	var hasher builder.Hasher
+
+	sourceHash, err := hasher.Hash(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("image hash error: %v", err)
+	}
+
+	ext := filepath.Ext(srcName)
+	base := strings.TrimSuffix(srcName, ext)
+
+	if entry, ok := cache[srcPath]; ok && entry.SourceHash == sourceHash {
+		variants := parseVariantNames(entry.Variants, base, ext)
+		if variantFilesExist(outDir, variants) {
+			return variants, nil
+		}
+	}
+
+	if _, statErr := os.Stat(srcPath); statErr != nil {
+		return nil, fmt.Errorf("image stat error: %v", statErr)
+	}
+
+	img, err := imaging.Open(srcPath)
+	if err != nil {
+		// A file imaging can't decode (e.g. SVG) is left un-resized
+		// rather than aborting the whole build, but it's still worth
+		// flagging: unlike a missing/unreadable file (handled above),
+		// this silently ships the unresized source with no <picture>.
+		log.Printf("image %s: skipping responsive variants, imaging could not decode it: %v", srcPath, err)
+		return nil, nil
+	}
+
+	sourceWidth := img.Bounds().Dx()
+
+	var variants []imageVariant
+	var names []string
+
+	for _, width := range responsiveWidths {
+		if width >= sourceWidth {
+			continue
+		}
+
+		resized := imaging.Resize(img, width, 0, imaging.Lanczos)
+		name := fmt.Sprintf("%s-%d%s", base, width, ext)
+
+		if err := imaging.Save(resized, filepath.Join(outDir, name)); err != nil {
+			return nil, fmt.Errorf("image save error: %v", err)
+		}
+
+		variants = append(variants, imageVariant{width: width, name: name})
+		names = append(names, name)
+	}
+
+	cache[srcPath] = imageCacheEntry{SourceHash: sourceHash, Variants: names}
+
+	return variants, nil
+}
+
+// variantFilesExist reports whether every derivative the cache remembers
+// generating is still present in outDir, so a stale or cleared public/
+// directory doesn't produce <picture> sources pointing at missing files.
+func variantFilesExist(outDir string, variants []imageVariant) bool {
+	for _, v := range variants {
+		if _, err := os.Stat(filepath.Join(outDir, v.name)); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseVariantNames(names []string, base, ext string) []imageVariant {
+	var variants []imageVariant
+
+	for _, name := range names {
+		widthStr := strings.TrimSuffix(strings.TrimPrefix(name, base+"-"), ext)
+		width, err := strconv.Atoi(widthStr)
+		if err != nil {
+			continue
+		}
+		variants = append(variants, imageVariant{width: width, name: name})
+	}
+
+	return variants
+}
+
+// rewriteImage resolves a bundle post's <img> against its public output
+// directory and, when responsive derivatives were generated, replaces it
+// with a <picture>/srcset.
+func rewriteImage(img *goquery.Selection, src postSource, cache imageCache) error {
+	srcAttr, ok := img.Attr("src")
+	if !ok || !isRelativeAssetURL(srcAttr) {
+		return nil
+	}
+
+	srcPath := filepath.Join(src.bundleDir, srcAttr)
+	outDir := filepath.Join("public/posts", src.slug)
+	publicSrc := "/posts/" + src.slug + "/" + srcAttr
+
+	variants, err := resizeImageVariants(srcPath, outDir, srcAttr, cache)
+	if err != nil {
+		return err
+	}
+
+	if len(variants) == 0 {
+		img.SetAttr("src", publicSrc)
+		return nil
+	}
+
+	var srcset []string
+	for _, v := range variants {
+		srcset = append(srcset, fmt.Sprintf("/posts/%s/%s %dw", src.slug, v.name, v.width))
+	}
+
+	alt, _ := img.Attr("alt")
+	pictureHTML := fmt.Sprintf(
+		`<picture><source srcset="%s" sizes="(max-width: 960px) 100vw, 960px"><img src="%s" alt="%s" loading="lazy"></picture>`,
+		strings.Join(srcset, ", "), publicSrc, html.EscapeString(alt),
+	)
+
+	img.ReplaceWithHtml(pictureHTML)
+
+	return nil
+}
@@ -0,0 +1,30 @@
+// Package builder provides incremental-build helpers shared by the build
+// pipeline, such as content hashing for change detection.
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Hasher computes content hashes of files for incremental build caching.
+type Hasher struct{}
+
+// Hash returns the hex-encoded SHA-256 digest of the file at path.
+func (Hasher) Hash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("hash open error: %v", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash read error: %v", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
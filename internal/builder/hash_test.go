@@ -0,0 +1,45 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHasherHash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "post.md")
+
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatalf("setup write failed: %v", err)
+	}
+
+	var h Hasher
+
+	first, err := h.Hash(path)
+	if err != nil {
+		t.Fatalf("Hash returned error: %v", err)
+	}
+
+	second, err := h.Hash(path)
+	if err != nil {
+		t.Fatalf("Hash returned error: %v", err)
+	}
+
+	if first != second {
+		t.Errorf("expected stable hash for unchanged file, got %q then %q", first, second)
+	}
+
+	if err := os.WriteFile(path, []byte("hello world"), 0644); err != nil {
+		t.Fatalf("rewrite failed: %v", err)
+	}
+
+	changed, err := h.Hash(path)
+	if err != nil {
+		t.Fatalf("Hash returned error: %v", err)
+	}
+
+	if changed == first {
+		t.Errorf("expected hash to change after content change")
+	}
+}
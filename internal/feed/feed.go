@@ -0,0 +1,101 @@
+// Package feed renders Atom 1.0 feeds for the blog build pipeline.
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Entry is a single Atom feed entry.
+type Entry struct {
+	Title       string
+	Description string
+	URL         string
+	Updated     time.Time
+	Content     string
+}
+
+// Feed is everything needed to render one Atom feed document.
+type Feed struct {
+	Title   string
+	Author  string
+	FeedURL string
+	SiteURL string
+	Updated time.Time
+	Entries []Entry
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Summary string      `xml:"summary"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",cdata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Author  atomAuthor  `xml:"author"`
+	Link    []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// WriteAtom marshals feed as an Atom 1.0 document and writes it to w.
+func WriteAtom(w io.Writer, feed Feed) error {
+	out := atomFeed{
+		Xmlns:  "http://www.w3.org/2005/Atom",
+		Title:  feed.Title,
+		ID:     feed.SiteURL,
+		Author: atomAuthor{Name: feed.Author},
+		Link: []atomLink{
+			{Rel: "self", Href: feed.FeedURL, Type: "application/atom+xml"},
+			{Rel: "alternate", Href: feed.SiteURL, Type: "text/html"},
+		},
+		Updated: feed.Updated.Format(time.RFC3339),
+	}
+
+	for _, e := range feed.Entries {
+		out.Entries = append(out.Entries, atomEntry{
+			Title:   e.Title,
+			ID:      e.URL,
+			Link:    atomLink{Rel: "alternate", Href: e.URL, Type: "text/html"},
+			Updated: e.Updated.Format(time.RFC3339),
+			Summary: e.Description,
+			Content: atomContent{Type: "html", Body: e.Content},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("feed header write error: %v", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("feed encode error: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package feed
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteAtom(t *testing.T) {
+	f := Feed{
+		Title:   "Test Blog",
+		Author:  "Jane Doe",
+		FeedURL: "https://example.com/feed.xml",
+		SiteURL: "https://example.com",
+		Updated: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Entries: []Entry{
+			{
+				Title:       "Hello & Welcome",
+				Description: "An intro post",
+				URL:         "https://example.com/posts/hello.html",
+				Updated:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Content:     "<p>hi</p>",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAtom(&buf, f); err != nil {
+		t.Fatalf("WriteAtom returned error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "Hello &amp; Welcome") {
+		t.Errorf("expected entry title to be XML-escaped, got: %s", out)
+	}
+	if !strings.Contains(out, "https://example.com/posts/hello.html") {
+		t.Errorf("expected entry URL in output, got: %s", out)
+	}
+}
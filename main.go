@@ -2,19 +2,23 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"html/template"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/adrg/frontmatter"
 	"github.com/yuin/goldmark"
 	highlighting "github.com/yuin/goldmark-highlighting/v2"
+
+	"github.com/aroki1/blog-source/internal/builder"
 )
 
 type PostMetadata struct {
@@ -24,15 +28,65 @@ type PostMetadata struct {
 	Date        time.Time `toml:"date"`
 	Language    string    `toml:"language"`
 	Tags        []string  `toml:"tags"`
+
+	// TranslationKey correlates translations of the same logical post
+	// across languages; posts sharing one populate each other's
+	// PostData.Translations.
+	TranslationKey string `toml:"translation_key"`
 }
 
 type PostData struct {
 	Metadata PostMetadata
 	Content  template.HTML
 	Page     string
+
+	// SourceHash is the SHA-256 of the post's source markdown, used by the
+	// incremental build cache to detect unchanged posts.
+	SourceHash string
+
+	// BundleDir is the posts/<slug>/ directory backing a page bundle post,
+	// or empty for a flat posts/<slug>.md post.
+	BundleDir string
+
+	// Translations maps language -> absolute URL for every other language
+	// sharing this post's Metadata.TranslationKey. template/post.html
+	// should range over it to render hreflang alternates and a language
+	// switcher, the same way template/tag.html links posts; that template
+	// (and header.html/footer.html, which every page template requires)
+	// isn't present in this checkout, so the data is populated and ready
+	// but unconsumed until those templates exist.
+	Translations map[string]string
 }
 
 func main() {
+	serveFlag := flag.Bool("serve", false, "run a local dev server with live reload instead of a one-off build")
+	flag.Parse()
+
+	if *serveFlag {
+		if err := Serve(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := Build(Config{}); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Config controls how Build renders the site.
+type Config struct {
+	// Dev enables live-reload script injection for the local dev server.
+	Dev bool
+}
+
+// Build runs the full static site generation pipeline: it renders every
+// post, a per-language index and feed, tag pages, the about page, and the
+// sitemap. Posts whose source and templates are unchanged since the last
+// build (tracked in .build-cache.json) are skipped, and the language
+// indexes/tags/feeds/sitemap are only regenerated when at least one post
+// changed. public/index.html redirects to the default language's index.
+func Build(cfg Config) error {
 	mdRenderer := goldmark.New(
 		goldmark.WithExtensions(
 			highlighting.NewHighlighting(
@@ -41,88 +95,216 @@ func main() {
 		),
 	)
 
-	err := os.RemoveAll("public")
+	err := os.MkdirAll("public/posts", 0755)
 	if err != nil {
-		log.Fatal(err)
-	}
-
-	err = os.MkdirAll("public", 0755)
-	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	err = os.Mkdir("public/posts", 0755)
+	err = copyFile("static/style.css", "public/style.css")
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("error copying styles: %v", err)
 	}
 
-	err = copyFile("static/style.css", "public/style.css")
+	postsData, err := getAllpostData(mdRenderer)
 	if err != nil {
-		log.Fatal("Error copying styles: ", err)
+		return err
 	}
 
-	postsData, err := getAllpostData(mdRenderer)
+	err = processPostAssets(postsData)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	sort.Slice(postsData, func(i, j int) bool {
 		return postsData[i].Metadata.Date.After(postsData[j].Metadata.Date)
 	})
 
-	err = ParsepostsMDToHTML(mdRenderer, postsData)
+	siteConfig, err := loadSiteConfig("config.toml")
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	// A post's Language is the single source of truth for where it's
+	// canonically served (public/<lang>/posts/<slug>.html); posts that
+	// don't set one fall back to the site's default language.
+	for i := range postsData {
+		if postsData[i].Metadata.Language == "" {
+			postsData[i].Metadata.Language = siteConfig.Language
+		}
 	}
 
-	err = ParseIndexTemplateToHTML(mdRenderer, postsData)
+	populateTranslations(postsData, siteConfig)
+
+	changed, err := ParsepostsMDToHTML(mdRenderer, postsData, cfg.Dev)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	err = ParseAboutPage()
+	if !changed && !cfg.Dev {
+		if _, statErr := os.Stat(filepath.Join("public", siteConfig.Language, "index.html")); statErr == nil {
+			return nil
+		}
+	}
+
+	byLang := partitionByLanguage(postsData, siteConfig.Language)
+
+	langs := make([]string, 0, len(byLang))
+	for lang := range byLang {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	for _, lang := range langs {
+		langPosts := byLang[lang]
+
+		err = ParseIndexTemplateToHTML(mdRenderer, langPosts, filepath.Join("public", lang, "index.html"), lang, cfg.Dev)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ParseRootRedirectPage(siteConfig, langs)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	err = ParseTagPagesToHTML(postsData, cfg.Dev)
+	if err != nil {
+		return err
+	}
+
+	err = ParseAboutPage(cfg.Dev)
+	if err != nil {
+		return err
+	}
+
+	err = GenerateFeeds(siteConfig, postsData)
+	if err != nil {
+		return err
+	}
+
+	err = GenerateLanguageFeeds(siteConfig, byLang)
+	if err != nil {
+		return err
+	}
+
+	err = GenerateSitemap(siteConfig, postsData)
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
 
+// getAllpostData reads and renders every flat post (posts/<slug>.md) and
+// page bundle post (posts/<slug>/index.md) concurrently, using a worker
+// pool sized to the number of available CPUs.
 func getAllpostData(mdRenderer goldmark.Markdown) ([]PostData, error) {
-	var posts []PostData
-
-	filenames, err := filepath.Glob("posts/*.md")
+	sources, err := discoverPostSources()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	for _, filename := range filenames {
-		slug := strings.TrimPrefix(filename, "posts/")
-		slug = strings.TrimSuffix(slug, ".md")
+	posts := make([]PostData, len(sources))
+	errs := make([]error, len(sources))
+
+	sem := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	var hasher builder.Hasher
+
+	for i, src := range sources {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, src postSource) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// read markdown
+			postMarkdown, err := Read(src.mdPath)
+			if err != nil {
+				errs[i] = fmt.Errorf("post Read error: %v", err)
+				return
+			}
+
+			rest, postData, err := getpostData(postMarkdown)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			// convert markdown to html
+			var buf bytes.Buffer
+			err = mdRenderer.Convert([]byte(rest), &buf)
+			if err != nil {
+				errs[i] = fmt.Errorf("Markdown to html convert error: %v", err)
+				return
+			}
+
+			sourceHash, err := hasher.Hash(src.mdPath)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			postData.Content = template.HTML(buf.String())
+			postData.Metadata.Slug = src.slug
+			postData.SourceHash = sourceHash
+			postData.BundleDir = src.bundleDir
+			posts[i] = postData
+		}(i, src)
+	}
 
-		// read markdown
-		postMarkdown, err := Read(slug)
+	wg.Wait()
+
+	for _, err := range errs {
 		if err != nil {
-			return posts, fmt.Errorf("post Read error: %v", err)
+			return nil, err
+		}
+	}
+
+	return posts, nil
+}
 
+// processPostAssets copies each page bundle's sibling assets into its
+// public output directory, rewrites relative image/link URLs (and
+// responsive image derivatives) in the already-rendered post content, and
+// prunes bundle output and image cache entries left behind by a bundle
+// post that's since been renamed or deleted.
+func processPostAssets(postsData []PostData) error {
+	cache := loadImageCache(imageCachePath)
+
+	for i := range postsData {
+		post := &postsData[i]
+		if post.BundleDir == "" {
+			continue
 		}
 
-		rest, postData, err := getpostData(postMarkdown)
-		if err != nil {
-			return posts, err
+		src := postSource{slug: post.Metadata.Slug, bundleDir: post.BundleDir}
+
+		if err := copyPostAssets(src); err != nil {
+			return err
 		}
 
-		// convert markdown to html
-		var buf bytes.Buffer
-		err = mdRenderer.Convert([]byte(rest), &buf)
+		rewritten, err := rewritePostAssetURLs(string(post.Content), src, cache)
 		if err != nil {
-			return posts, fmt.Errorf("Markdown to html convert error: %v", err)
-
+			return err
 		}
 
-		postData.Content = template.HTML(buf.String())
-		postData.Metadata.Slug = slug
-		posts = append(posts, postData)
+		post.Content = template.HTML(rewritten)
 	}
-	return posts, nil
+
+	if err := pruneStaleBundleAssets(postsData); err != nil {
+		return err
+	}
+
+	for srcPath := range cache {
+		if _, err := os.Stat(srcPath); err != nil {
+			delete(cache, srcPath)
+		}
+	}
+
+	return saveImageCache(imageCachePath, cache)
 }
 
 func getpostData(postMarkdown io.Reader) (string, PostData, error) {
@@ -140,69 +322,145 @@ func getpostData(postMarkdown io.Reader) (string, PostData, error) {
 	return string(rest), postData, nil
 }
 
-func ParsepostsMDToHTML(mdRenderer goldmark.Markdown, postsData []PostData) error {
+// ParsepostsMDToHTML renders each post's HTML file concurrently, skipping
+// posts whose source and template hashes match the build cache, removes
+// the output of any post the cache remembers but postsData no longer
+// contains, and reports whether anything was actually (re-)rendered or
+// pruned.
+func ParsepostsMDToHTML(mdRenderer goldmark.Markdown, postsData []PostData, dev bool) (bool, error) {
 	tpl := template.Must(template.ParseFiles("template/post.html", "template/header.html", "template/footer.html"))
 
-	for _, post := range postsData {
-		// create .html file
-		file, err := os.Create("public/posts/" + post.Metadata.Slug + ".html")
-		if err != nil {
-			return fmt.Errorf("HTML File creation error: %v", err)
+	templateHash, err := hashTemplates("template/post.html", "template/header.html", "template/footer.html")
+	if err != nil {
+		return false, err
+	}
+
+	// config.toml and the non-post templates affect every generated page,
+	// not just posts, so a change to any of them must force a full
+	// rebuild even when no post's own source or template changed.
+	siteHash, err := hashTemplates("config.toml", "template/index.html", "template/tags.html", "template/tag.html", "template/about.html")
+	if err != nil {
+		return false, err
+	}
+
+	cache := loadBuildCache(buildCachePath)
+	siteChanged := cache[siteCacheKey].TemplateHash != siteHash
+
+	entries := make([]cacheEntry, len(postsData))
+	changed := make([]bool, len(postsData))
+	errs := make([]error, len(postsData))
+
+	sem := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+
+	for i, post := range postsData {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, post PostData) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// The post's own language is the one source of truth for where
+			// it's canonically served from, so feeds/sitemap/tag pages can
+			// all link to the same place without a separate flat copy.
+			cacheKey := post.Metadata.Language + "/" + post.Metadata.Slug
+			outPath := filepath.Join("public", post.Metadata.Language, "posts", post.Metadata.Slug+".html")
+
+			prev, ok := cache[cacheKey]
+			if !dev && ok && prev.SourceHash == post.SourceHash && prev.TemplateHash == templateHash {
+				if _, statErr := os.Stat(outPath); statErr == nil {
+					entries[i] = prev
+					return
+				}
+			}
+
+			if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+				errs[i] = fmt.Errorf("post output dir error: %v", err)
+				return
+			}
+
+			if err := renderTemplate(tpl, post, outPath, dev); err != nil {
+				errs[i] = err
+				return
+			}
+
+			entries[i] = cacheEntry{SourceHash: post.SourceHash, TemplateHash: templateHash, OutputPath: outPath}
+			changed[i] = true
+		}(i, post)
+	}
+
+	wg.Wait()
+
+	liveKeys := make(map[string]bool, len(postsData))
+	anyChanged := false
+	for i, post := range postsData {
+		if errs[i] != nil {
+			return false, errs[i]
 		}
 
-		defer file.Close()
+		key := post.Metadata.Language + "/" + post.Metadata.Slug
+		liveKeys[key] = true
+		cache[key] = entries[i]
+		if changed[i] {
+			anyChanged = true
+		}
+	}
 
-		err = tpl.Execute(file, post)
-		if err != nil {
-			return fmt.Errorf("Tempalate Execute error: %v", err)
+	// public/ is never wiped wholesale, so a post renamed or removed since
+	// the last build would otherwise leave its old HTML file behind
+	// forever; prune anything the cache remembers rendering that no
+	// current post claims.
+	for key, entry := range cache {
+		if key == siteCacheKey || liveKeys[key] {
+			continue
+		}
 
+		if err := os.Remove(entry.OutputPath); err != nil && !os.IsNotExist(err) {
+			return false, fmt.Errorf("stale post removal error: %v", err)
 		}
+
+		delete(cache, key)
+		anyChanged = true
 	}
-	return nil
+
+	if siteChanged {
+		anyChanged = true
+	}
+	cache[siteCacheKey] = cacheEntry{TemplateHash: siteHash}
+
+	if err := saveBuildCache(buildCachePath, cache); err != nil {
+		return anyChanged, err
+	}
+
+	return anyChanged, nil
 }
 
 type IndexData struct {
-	Posts []PostData
-	Page  string
+	Posts    []PostData
+	Page     string
+	Language string
 }
 
-func ParseIndexTemplateToHTML(mdRenderer goldmark.Markdown, postsData []PostData) error {
+func ParseIndexTemplateToHTML(mdRenderer goldmark.Markdown, postsData []PostData, outPath, lang string, dev bool) error {
 	tpl := template.Must(template.ParseFiles("template/index.html", "template/header.html", "template/footer.html"))
 
-	file, err := os.Create("public/index.html")
-	if err != nil {
-		return fmt.Errorf("HTML File creation error: %v", err)
-	}
-
-	defer file.Close()
-
 	indexData := IndexData{
-		Posts: postsData,
-		Page:  "index",
+		Posts:    postsData,
+		Page:     "index",
+		Language: lang,
 	}
 
-	err = tpl.Execute(file, indexData)
-	if err != nil {
-		return fmt.Errorf("Tempalate Execute error: %v", err)
-	}
-
-	return nil
+	return renderTemplate(tpl, indexData, outPath, dev)
 }
 
-func ParseAboutPage() error {
+func ParseAboutPage(dev bool) error {
 	tpl := template.Must(template.ParseFiles(
 		"template/about.html",
 		"template/header.html",
 		"template/footer.html",
 	))
 
-	file, err := os.Create("public/about.html")
-	if err != nil {
-		return err
-	}
-
-	defer file.Close()
-
 	data := struct {
 		Page  string
 		Title string
@@ -211,11 +469,44 @@ func ParseAboutPage() error {
 		Title: "About me",
 	}
 
-	return tpl.Execute(file, data)
+	return renderTemplate(tpl, data, "public/about.html", dev)
+}
+
+// reloadSnippet is injected before </body> in dev mode so the browser
+// reconnects to the build's SSE endpoint and reloads on the next rebuild.
+const reloadSnippet = `<script>
+(function() {
+	var source = new EventSource("/_reload");
+	source.onmessage = function() { location.reload(); };
+})();
+</script>
+</body>`
+
+// renderTemplate executes tpl with data and writes the result to outPath,
+// injecting the live-reload snippet before </body> when dev is true.
+func renderTemplate(tpl *template.Template, data any, outPath string, dev bool) error {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("Tempalate Execute error: %v", err)
+	}
+
+	out := buf.Bytes()
+	if dev {
+		out = bytes.Replace(out, []byte("</body>"), []byte(reloadSnippet), 1)
+	}
+
+	file, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("HTML File creation error: %v", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(out)
+	return err
 }
 
-func Read(slug string) (io.Reader, error) {
-	f, err := os.Open("posts/" + slug + ".md")
+func Read(path string) (io.Reader, error) {
+	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
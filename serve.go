@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const rebuildDebounce = 100 * time.Millisecond
+
+// Serve runs an HTTP server rooted at public/, rebuilding the site whenever
+// posts/, template/, or static/ change (including page bundle
+// subdirectories and any created after the server starts), and notifying
+// connected browsers over Server-Sent Events on /_reload.
+func Serve() error {
+	if err := Build(Config{Dev: true}); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, dir := range []string{"posts", "template", "static"} {
+		if err := addRecursive(watcher, dir); err != nil {
+			return err
+		}
+	}
+
+	reloads := newReloadHub()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir("public")))
+	mux.HandleFunc("/_reload", reloads.serveHTTP)
+
+	server := &http.Server{Addr: ":8080", Handler: mux}
+
+	go watchAndRebuild(watcher, reloads)
+
+	go func() {
+		log.Printf("serving public/ on %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return server.Shutdown(shutdownCtx)
+}
+
+// addRecursive adds root and every directory beneath it to watcher, since
+// fsnotify only watches the directories it's explicitly given and page
+// bundles (posts/<slug>/) would otherwise go unwatched.
+func addRecursive(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+func watchAndRebuild(watcher *fsnotify.Watcher, reloads *reloadHub) {
+	var timer *time.Timer
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == 0 {
+				continue
+			}
+
+			// A newly created bundle directory (posts/<slug>/) needs to be
+			// watched itself, or its files would go unnoticed until the
+			// next time the server restarts.
+			if event.Op&fsnotify.Create != 0 {
+				if info, statErr := os.Stat(event.Name); statErr == nil && info.IsDir() {
+					if err := addRecursive(watcher, event.Name); err != nil {
+						log.Printf("watch new directory error: %v", err)
+					}
+				}
+			}
+
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(rebuildDebounce, func() {
+				if err := Build(Config{Dev: true}); err != nil {
+					log.Printf("rebuild error: %v", err)
+					return
+				}
+				reloads.notify()
+			})
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("watcher error: %v", err)
+		}
+	}
+}
+
+// reloadHub fans out build-complete notifications to every browser
+// connected to /_reload over Server-Sent Events.
+type reloadHub struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+func newReloadHub() *reloadHub {
+	return &reloadHub{clients: make(map[chan struct{}]struct{})}
+}
+
+func (h *reloadHub) notify() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (h *reloadHub) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	h.clients[ch] = struct{}{}
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.clients, ch)
+		h.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ch:
+			if _, err := w.Write([]byte("data: reload\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// SlugifyTag normalizes a tag into a URL-safe slug: lowercased, spaces
+// replaced with dashes, and unicode-folded to ASCII where possible.
+func SlugifyTag(tag string) string {
+	t := transform.NewReader(strings.NewReader(tag), transform.Chain(
+		norm.NFD,
+		runes.Remove(runes.In(unicode.Mn)),
+		norm.NFC,
+	))
+
+	b, err := io.ReadAll(t)
+	if err != nil {
+		b = []byte(tag)
+	}
+
+	slug := strings.ToLower(string(b))
+	slug = strings.Join(strings.Fields(slug), "-")
+
+	return slug
+}
+
+// TagSummary is one row of the taxonomy index: a tag and how many posts
+// carry it.
+type TagSummary struct {
+	Name  string
+	Slug  string
+	Count int
+}
+
+// TagPage is the set of posts rendered for a single tag's listing page.
+type TagPage struct {
+	Name  string
+	Slug  string
+	Posts []PostData
+	Page  string
+}
+
+// TagsIndexData is the data passed to template/tags.html.
+type TagsIndexData struct {
+	Tags []TagSummary
+	Page string
+}
+
+// buildTagIndex groups posts by their tag's slug rather than its raw
+// spelling, so tags that only differ by case or accents (e.g. "Go" vs
+// "GO") land on one shared page instead of silently overwriting each
+// other's output; the alphabetically first spelling is used as the
+// display name, and a collision is logged.
+func buildTagIndex(postsData []PostData) map[string][]PostData {
+	bySlug := make(map[string][]PostData)
+	displayName := make(map[string]string)
+
+	for _, post := range postsData {
+		for _, tag := range post.Metadata.Tags {
+			slug := SlugifyTag(tag)
+			bySlug[slug] = append(bySlug[slug], post)
+
+			switch existing, ok := displayName[slug]; {
+			case !ok:
+				displayName[slug] = tag
+			case existing != tag:
+				if tag < existing {
+					displayName[slug] = tag
+				}
+				log.Printf("tags %q and %q both slugify to %q; merging into %q", existing, tag, slug, displayName[slug])
+			}
+		}
+	}
+
+	byTag := make(map[string][]PostData, len(bySlug))
+	for slug, posts := range bySlug {
+		byTag[displayName[slug]] = posts
+	}
+
+	return byTag
+}
+
+// ParseTagPagesToHTML renders public/tags/index.html and, for every tag
+// used by at least one post, public/tags/<slug>/index.html.
+func ParseTagPagesToHTML(postsData []PostData, dev bool) error {
+	byTag := buildTagIndex(postsData)
+
+	var summaries []TagSummary
+	for tag, posts := range byTag {
+		summaries = append(summaries, TagSummary{
+			Name:  tag,
+			Slug:  SlugifyTag(tag),
+			Count: len(posts),
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+
+	if err := os.MkdirAll("public/tags", 0755); err != nil {
+		return fmt.Errorf("tags dir creation error: %v", err)
+	}
+
+	tagsTpl := template.Must(template.ParseFiles("template/tags.html", "template/header.html", "template/footer.html"))
+	err := renderTemplate(tagsTpl, TagsIndexData{Tags: summaries, Page: "tags"}, "public/tags/index.html", dev)
+	if err != nil {
+		return err
+	}
+
+	tagTpl := template.Must(template.ParseFiles("template/tag.html", "template/header.html", "template/footer.html"))
+
+	for tag, posts := range byTag {
+		slug := SlugifyTag(tag)
+		dir := filepath.Join("public/tags", slug)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("tag dir creation error: %v", err)
+		}
+
+		data := TagPage{Name: tag, Slug: slug, Posts: posts, Page: "tag"}
+		if err := renderTemplate(tagTpl, data, filepath.Join(dir, "index.html"), dev); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
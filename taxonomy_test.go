@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestBuildTagIndexMergesSlugCollisions(t *testing.T) {
+	posts := []PostData{
+		{Metadata: PostMetadata{Slug: "a", Tags: []string{"Go"}}},
+		{Metadata: PostMetadata{Slug: "b", Tags: []string{"GO"}}},
+	}
+
+	byTag := buildTagIndex(posts)
+
+	if len(byTag) != 1 {
+		t.Fatalf("expected colliding tags to merge into one entry, got %v", byTag)
+	}
+	if posts, ok := byTag["GO"]; !ok || len(posts) != 2 {
+		t.Errorf("expected merged entry under the alphabetically first spelling with both posts, got %v", byTag)
+	}
+}
+
+func TestSlugifyTag(t *testing.T) {
+	cases := map[string]string{
+		"Go":            "go",
+		"Side Projects": "side-projects",
+		"Café":          "cafe",
+	}
+
+	for in, want := range cases {
+		if got := SlugifyTag(in); got != want {
+			t.Errorf("SlugifyTag(%q) = %q, want %q", in, got, want)
+		}
+	}
+}